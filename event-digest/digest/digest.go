@@ -0,0 +1,98 @@
+// Package digest computes and represents per-hour aggregate counts for
+// GH Archive event files.
+package digest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// FilenameRE matches the GH Archive event file naming convention
+// (YYYY-MM-DD-H), as opposed to any other file GHC_EVENTS_PATH might
+// hold (a digest or summary file, say)
+var FilenameRE = regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})-(\d{1,2})`)
+
+// ID is the SHA-256 hash of a decompressed event stream (hex-encoded by
+// String and in JSON), used to key a cached Digest to the content it was
+// computed from
+type ID [sha256.Size]byte
+
+// ParseID decodes a hex-encoded ID, as produced by ID.String
+func ParseID(s string) (ID, error) {
+	var id ID
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return id, err
+	}
+	if len(b) != len(id) {
+		return id, fmt.Errorf("invalid length for id %q: %v", s, len(b))
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+func (id ID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Equal reports whether id and other identify the same content
+func (id ID) Equal(other ID) bool {
+	return id == other
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(id.String())
+}
+
+func (id *ID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseID(s)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// Digest contains all aggregate data for a specific hour; see
+// DigestSlice for slice-wide helpers
+type Digest struct {
+	Count    int       `json:"count"`
+	Date     time.Time `json:"date"`
+	SourceID ID        `json:"sourceId"`
+}
+
+// New builds a Digest for the event file at eventFilePath, parsing its
+// hour from the GH Archive filename convention (YYYY-MM-DD-H.json.*)
+func New(eventFilePath string, count int, sourceID ID) (*Digest, error) {
+	dateParts := FilenameRE.FindStringSubmatch(filepath.Base(eventFilePath))
+	if dateParts == nil {
+		return nil, fmt.Errorf("could not parse date from %q", eventFilePath)
+	}
+	year, _ := strconv.Atoi(dateParts[1])
+	month, _ := strconv.Atoi(dateParts[2])
+	day, _ := strconv.Atoi(dateParts[3])
+	hr, _ := strconv.Atoi(dateParts[4])
+
+	return &Digest{
+		Count:    count,
+		Date:     time.Date(year, time.Month(month), day, hr, 0, 0, 0, time.UTC),
+		SourceID: sourceID,
+	}, nil
+}
+
+// SortByDate returns digests ordered chronologically
+func SortByDate(digests DigestSlice) DigestSlice {
+	return digests.SortBy(func(x, y *Digest) bool {
+		return x.Date.Unix() < y.Date.Unix()
+	})
+}
@@ -0,0 +1,17 @@
+package digest
+
+import "sort"
+
+// DigestSlice is a slice of *Digest with a handful of slice-wide helpers,
+// kept alongside Digest itself rather than generated
+type DigestSlice []*Digest
+
+// SortBy returns a new DigestSlice ordered by the given less function
+func (s DigestSlice) SortBy(less func(x, y *Digest) bool) DigestSlice {
+	sorted := make(DigestSlice, len(s))
+	copy(sorted, s)
+	sort.Slice(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
@@ -0,0 +1,32 @@
+package digest
+
+import "testing"
+
+func TestNewParsesDateFromFilename(t *testing.T) {
+	d, err := New("/events/2016-01-02-3.json.gz", 5, ID{1})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, want := d.Date.Format("2006-01-02T15"), "2016-01-02T03"; got != want {
+		t.Errorf("New(...).Date = %v, want %v", got, want)
+	}
+	if d.Count != 5 {
+		t.Errorf("New(...).Count = %v, want 5", d.Count)
+	}
+}
+
+func TestNewRejectsUnparsableFilename(t *testing.T) {
+	if _, err := New("/events/summary.json.gz", 0, ID{}); err == nil {
+		t.Error("New with non-date filename: got nil error, want non-nil")
+	}
+}
+
+func TestSortByDateOrdersChronologically(t *testing.T) {
+	later, _ := New("/events/2016-01-02-5.json.gz", 0, ID{})
+	earlier, _ := New("/events/2016-01-02-1.json.gz", 0, ID{})
+
+	sorted := SortByDate(DigestSlice{later, earlier})
+	if sorted[0] != earlier || sorted[1] != later {
+		t.Errorf("SortByDate = %v, want earlier before later", sorted)
+	}
+}
@@ -0,0 +1,74 @@
+package digest
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/modulexcite/github-contributions/event-digest/digestpb"
+)
+
+// Encoding selects how Digests and summaries are stored on disk
+type Encoding string
+
+const (
+	EncodingJSON  Encoding = "json"
+	EncodingProto Encoding = "proto"
+	EncodingBoth  Encoding = "both"
+)
+
+// EncodingFromEnv reads GHC_ENCODING, defaulting to EncodingJSON
+func EncodingFromEnv() Encoding {
+	switch Encoding(os.Getenv("GHC_ENCODING")) {
+	case EncodingProto:
+		return EncodingProto
+	case EncodingBoth:
+		return EncodingBoth
+	default:
+		return EncodingJSON
+	}
+}
+
+// ToProto converts d to its protobuf representation
+func ToProto(d *Digest) *digestpb.Digest {
+	return &digestpb.Digest{
+		SourceId: d.SourceID[:],
+		Count:    int64(d.Count),
+		DateUnix: d.Date.Unix(),
+	}
+}
+
+// FromProto converts a protobuf Digest back into a Digest. It rejects a
+// SourceId of the wrong length rather than silently zero-padding or
+// truncating it via copy, since a malformed ID would otherwise collide
+// with whatever content happens to hash to the padded/truncated value
+func FromProto(p *digestpb.Digest) (*Digest, error) {
+	var id ID
+	if len(p.SourceId) != len(id) {
+		return nil, fmt.Errorf("invalid length for proto source id: got %v, want %v",
+			len(p.SourceId), len(id))
+	}
+	copy(id[:], p.SourceId)
+
+	return &Digest{
+		Count:    int(p.Count),
+		Date:     time.Unix(p.DateUnix, 0).UTC(),
+		SourceID: id,
+	}, nil
+}
+
+// Canonical normalizes a Digest for cross-encoding comparison: JSON
+// preserves sub-second precision, proto only stores unix seconds, so
+// dates are converted to UTC (dropping any monotonic reading) and
+// truncated to seconds before comparing
+func Canonical(d *Digest) Digest {
+	c := *d
+	c.Date = c.Date.UTC().Truncate(time.Second)
+	return c
+}
+
+// Equal reports whether two digests are equal once canonicalized
+func Equal(a, b *Digest) bool {
+	return reflect.DeepEqual(Canonical(a), Canonical(b))
+}
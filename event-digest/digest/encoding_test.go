@@ -0,0 +1,54 @@
+package digest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCanonicalTruncatesToSeconds(t *testing.T) {
+	d := &Digest{
+		Count: 1,
+		Date:  time.Date(2016, 1, 2, 3, 0, 0, 500, time.UTC),
+	}
+	c := Canonical(d)
+	if !c.Date.Equal(d.Date.Truncate(time.Second)) {
+		t.Errorf("Canonical(%v).Date = %v, want truncated to seconds", d, c.Date)
+	}
+}
+
+func TestEqualDetectsDivergence(t *testing.T) {
+	a := &Digest{Count: 1, Date: time.Unix(1000, 0).UTC()}
+	b := &Digest{Count: 2, Date: time.Unix(1000, 0).UTC()}
+	if Equal(a, b) {
+		t.Errorf("Equal(%+v, %+v) = true, want false", a, b)
+	}
+
+	b.Count = 1
+	if !Equal(a, b) {
+		t.Errorf("Equal(%+v, %+v) = false, want true", a, b)
+	}
+}
+
+func TestToProtoFromProtoRoundTrips(t *testing.T) {
+	d := &Digest{
+		Count:    42,
+		Date:     time.Unix(1234567890, 0).UTC(),
+		SourceID: ID{1, 2, 3},
+	}
+	got, err := FromProto(ToProto(d))
+	if err != nil {
+		t.Fatalf("FromProto: %v", err)
+	}
+	if !Equal(d, got) {
+		t.Errorf("FromProto(ToProto(%+v)) = %+v, want equal", d, got)
+	}
+}
+
+func TestFromProtoRejectsTruncatedSourceID(t *testing.T) {
+	p := ToProto(&Digest{Count: 1, Date: time.Unix(0, 0)})
+	p.SourceId = p.SourceId[:len(p.SourceId)-1]
+
+	if _, err := FromProto(p); err == nil {
+		t.Error("FromProto with truncated SourceId: got nil error, want non-nil")
+	}
+}
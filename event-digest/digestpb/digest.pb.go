@@ -0,0 +1,71 @@
+// Package digestpb contains the protobuf bindings for digest.proto.
+// Hand-maintained in the style of protoc-gen-go's legacy output, since
+// no protoc/protoc-gen-go build step is wired into this repo; keep it in
+// sync with digest.proto by hand
+package digestpb
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type Digest struct {
+	SourceId             []byte   `protobuf:"bytes,1,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Count                int64    `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	DateUnix             int64    `protobuf:"varint,3,opt,name=date_unix,json=dateUnix,proto3" json:"date_unix,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Digest) Reset()         { *m = Digest{} }
+func (m *Digest) String() string { return proto.CompactTextString(m) }
+func (*Digest) ProtoMessage()    {}
+
+func (m *Digest) GetSourceId() []byte {
+	if m != nil {
+		return m.SourceId
+	}
+	return nil
+}
+
+func (m *Digest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *Digest) GetDateUnix() int64 {
+	if m != nil {
+		return m.DateUnix
+	}
+	return 0
+}
+
+type Summary struct {
+	Digests              []*Digest `protobuf:"bytes,1,rep,name=digests,proto3" json:"digests,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Summary) Reset()         { *m = Summary{} }
+func (m *Summary) String() string { return proto.CompactTextString(m) }
+func (*Summary) ProtoMessage()    {}
+
+func (m *Summary) GetDigests() []*Digest {
+	if m != nil {
+		return m.Digests
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Digest)(nil), "digestpb.Digest")
+	proto.RegisterType((*Summary)(nil), "digestpb.Summary")
+}
@@ -0,0 +1,87 @@
+package extractor
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/modulexcite/github-contributions/event-digest/digest"
+)
+
+// archiveReaders maps an event archive's file extension to the
+// decompressor used to read it, so GH Archive mirrors and re-packers can
+// ship either gzip or zstd
+var archiveReaders = map[string]func(*os.File) (io.ReadCloser, error){
+	".gz": func(f *os.File) (io.ReadCloser, error) {
+		return gzip.NewReader(f)
+	},
+	".zst": func(f *os.File) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+}
+
+// Open opens the decompressed event stream for an already-open event
+// archive, dispatching on the archive's file extension
+func Open(eventFilePath string, f *os.File) (io.ReadCloser, error) {
+	ext := filepath.Ext(eventFilePath)
+	open, ok := archiveReaders[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported event archive extension %q", ext)
+	}
+	return open(f)
+}
+
+// Glob finds every supported event archive under dir. Matches are
+// anchored to the GH Archive filename convention rather than a bare
+// extension, so a digest or summary file written into dir (e.g.
+// summary.json.gz under GHC_DIGEST_COMPRESSION) is never mistaken for
+// an event archive to decode
+func Glob(dir string) ([]string, error) {
+	var files []string
+	for ext := range archiveReaders {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.json"+ext))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if digest.FilenameRE.MatchString(filepath.Base(m)) {
+				files = append(files, m)
+			}
+		}
+	}
+	return files, nil
+}
+
+// SourceID hashes the decompressed contents of an event file, so that a
+// re-downloaded or truncated GH Archive file produces a different ID
+func SourceID(eventFilePath string) (digest.ID, error) {
+	var id digest.ID
+
+	f, err := os.Open(eventFilePath)
+	if err != nil {
+		return id, err
+	}
+	defer f.Close()
+
+	r, err := Open(eventFilePath, f)
+	if err != nil {
+		return id, err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return id, err
+	}
+	copy(id[:], h.Sum(nil))
+	return id, nil
+}
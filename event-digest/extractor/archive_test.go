@@ -0,0 +1,69 @@
+package extractor
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeGzipFile(t *testing.T, path, contents string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer f.Close()
+
+	w := gzip.NewWriter(f)
+	if _, err := w.Write([]byte(contents)); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+}
+
+func TestGlobExcludesNonEventFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeGzipFile(t, filepath.Join(dir, "2016-01-02-3.json.gz"), "[]")
+	writeGzipFile(t, filepath.Join(dir, "summary.json.gz"), "[]")
+
+	files, err := Glob(dir)
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "2016-01-02-3.json.gz" {
+		t.Errorf("Glob(%v) = %v, want only the event archive", dir, files)
+	}
+}
+
+func TestSourceIDDiffersOnContentChange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "2016-01-02-3.json.gz")
+	writeGzipFile(t, path, `{"type":"PushEvent"}`)
+	id1, err := SourceID(path)
+	if err != nil {
+		t.Fatalf("SourceID: %v", err)
+	}
+
+	writeGzipFile(t, path, `{"type":"WatchEvent"}`)
+	id2, err := SourceID(path)
+	if err != nil {
+		t.Fatalf("SourceID: %v", err)
+	}
+
+	if id1.Equal(id2) {
+		t.Errorf("SourceID did not change after content changed: both %v", id1)
+	}
+}
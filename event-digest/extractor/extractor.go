@@ -0,0 +1,66 @@
+// Package extractor streams GH Archive event records out of a
+// decompressed event file, extracting whichever facts a caller needs
+// (usernames, event-type counts, per-repo counts) from a single
+// io.Reader.
+package extractor
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// EventRecord is one transformed event
+type EventRecord struct {
+	Type  string      `json:"type"`
+	Actor ActorRecord `json:"actor"`
+	Repo  RepoRecord  `json:"repo"`
+}
+
+// ActorRecord is often nested in EventRecord
+type ActorRecord struct {
+	Username string `json:"login"`
+}
+
+// RepoRecord is often nested in EventRecord
+type RepoRecord struct {
+	Name string `json:"name"`
+}
+
+// Username identifies an event's actor; see UsernameSet for set methods
+type Username string
+
+// ExtractEventTypeCounts decodes each event in r and tallies how many
+// events were seen per GitHub event type (PushEvent, WatchEvent, ...)
+func ExtractEventTypeCounts(r io.Reader) (map[string]int, error) {
+	counts := map[string]int{}
+	decoder := json.NewDecoder(r)
+	for {
+		if !decoder.More() {
+			break
+		}
+		event := EventRecord{}
+		if err := decoder.Decode(&event); err != nil {
+			return nil, err
+		}
+		counts[event.Type]++
+	}
+	return counts, nil
+}
+
+// ExtractRepoStats decodes each event in r and tallies how many events
+// were seen per repository
+func ExtractRepoStats(r io.Reader) (map[string]int, error) {
+	counts := map[string]int{}
+	decoder := json.NewDecoder(r)
+	for {
+		if !decoder.More() {
+			break
+		}
+		event := EventRecord{}
+		if err := decoder.Decode(&event); err != nil {
+			return nil, err
+		}
+		counts[event.Repo.Name]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,54 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// maxScanTokenSize widens bufio.Scanner's default 64KiB token limit,
+// since a single GH Archive event can exceed it
+const maxScanTokenSize = 16 * 1024 * 1024
+
+// Scanner streams newline-delimited events out of a reader exactly
+// once, counting non-blank lines and decoding each event's actor login in the
+// same pass
+type Scanner struct {
+	scanner *bufio.Scanner
+	count   int
+}
+
+// NewScanner wraps r for a single-pass scan
+func NewScanner(r io.Reader) *Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxScanTokenSize)
+	return &Scanner{scanner: s}
+}
+
+// Scan reads events until EOF, incrementing Count for every
+// newline-delimited record and adding each actor's lower-cased username
+// to users
+func (s *Scanner) Scan(users UsernameSet) error {
+	for s.scanner.Scan() {
+		if len(s.scanner.Bytes()) == 0 {
+			continue
+		}
+		s.count++
+
+		event := EventRecord{}
+		dec := json.NewDecoder(bytes.NewReader(s.scanner.Bytes()))
+		if err := dec.Decode(&event); err != nil {
+			return err
+		}
+		event.Actor.Username = strings.ToLower(event.Actor.Username)
+		users.Add(Username(event.Actor.Username))
+	}
+	return s.scanner.Err()
+}
+
+// Count returns the number of events scanned so far
+func (s *Scanner) Count() int {
+	return s.count
+}
@@ -0,0 +1,37 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanCountsAndExtractsUsernames(t *testing.T) {
+	s := NewScanner(strings.NewReader(
+		`{"type":"PushEvent","actor":{"login":"Foo"}}` + "\n" +
+			`{"type":"WatchEvent","actor":{"login":"bar"}}` + "\n"))
+
+	users := UsernameSet{}
+	if err := s.Scan(users); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if s.Count() != 2 {
+		t.Errorf("Count() = %v, want 2", s.Count())
+	}
+	if !users.Contains("foo") || !users.Contains("bar") {
+		t.Errorf("users = %v, want foo and bar (lower-cased)", users)
+	}
+}
+
+func TestScanSkipsBlankLines(t *testing.T) {
+	s := NewScanner(strings.NewReader(
+		`{"type":"PushEvent","actor":{"login":"foo"}}` + "\n\n" +
+			`{"type":"WatchEvent","actor":{"login":"bar"}}` + "\n"))
+
+	users := UsernameSet{}
+	if err := s.Scan(users); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if s.Count() != 2 {
+		t.Errorf("Count() = %v, want 2 (blank line should not be counted)", s.Count())
+	}
+}
@@ -0,0 +1,52 @@
+package extractor
+
+// UsernameSet is a set of Usernames, implemented as a map[Username]struct{}
+// for minimal memory overhead. Hand-maintained rather than generated
+type UsernameSet map[Username]struct{}
+
+// NewUsernameSet creates a new UsernameSet containing the given items
+func NewUsernameSet(items ...Username) UsernameSet {
+	set := make(UsernameSet, len(items))
+	set.Add(items...)
+	return set
+}
+
+// Add adds items to the set
+func (set UsernameSet) Add(items ...Username) {
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+}
+
+// Remove removes items from the set
+func (set UsernameSet) Remove(items ...Username) {
+	for _, item := range items {
+		delete(set, item)
+	}
+}
+
+// Contains reports whether item is a member of the set
+func (set UsernameSet) Contains(item Username) bool {
+	_, ok := set[item]
+	return ok
+}
+
+// Clone returns a new UsernameSet with a copy of set's contents
+func (set UsernameSet) Clone() UsernameSet {
+	clone := make(UsernameSet, len(set))
+	for item := range set {
+		clone[item] = struct{}{}
+	}
+	return clone
+}
+
+// Difference returns a new UsernameSet of items in set but not in other
+func (set UsernameSet) Difference(other UsernameSet) UsernameSet {
+	diff := UsernameSet{}
+	for item := range set {
+		if !other.Contains(item) {
+			diff.Add(item)
+		}
+	}
+	return diff
+}
@@ -1,241 +1,231 @@
+// Command event-digest computes per-hour aggregate counts, and tracks
+// known usernames, for a directory of GH Archive event files.
 package main
 
 import (
-	"bytes"
-	"compress/gzip"
-	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"os"
-	"path/filepath"
-	"regexp"
+	"runtime"
 	"strconv"
-	"strings"
-	"time"
-)
+	"sync"
+	"sync/atomic"
 
-var (
-	eventFilenameRE = regexp.MustCompile(
-		`(\d{4})-(\d{2})-(\d{2})-(\d{1,2})`)
+	"github.com/modulexcite/github-contributions/event-digest/digest"
+	"github.com/modulexcite/github-contributions/event-digest/extractor"
+	"github.com/modulexcite/github-contributions/event-digest/store"
 )
 
-// Digest contains all aggregate data for specific hour
-// +gen * slice:"SortBy"
-type Digest struct {
-	Count int       `json:"count"`
-	Date  time.Time `json:"date"`
-}
-
-// EventRecord is one transformed event
-type EventRecord struct {
-	Actor ActorRecord `json:"actor"`
+// newStore builds the configured Store backend, honoring
+// GHC_STORE_BACKEND ("local", the default, or "s3")
+func newStore() store.Store {
+	switch os.Getenv("GHC_STORE_BACKEND") {
+	case "s3":
+		return &store.S3Store{
+			Bucket: os.Getenv("GHC_S3_BUCKET"),
+			Prefix: os.Getenv("GHC_S3_PREFIX"),
+		}
+	default:
+		return &store.LocalFSStore{
+			EventsPath:  os.Getenv("GHC_EVENTS_PATH"),
+			Encoding:    digest.EncodingFromEnv(),
+			Compression: os.Getenv("GHC_DIGEST_COMPRESSION"),
+		}
+	}
 }
 
-// ActorRecord is often nested in EventRecord
-type ActorRecord struct {
-	Username string `json:"login"`
+// concurrency returns the number of digest workers to run, honoring
+// GHC_CONCURRENCY and falling back to GOMAXPROCS
+func concurrency() int {
+	if v := os.Getenv("GHC_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return runtime.GOMAXPROCS(0)
 }
 
-// Username implements set methods
-// +gen set
-type Username string
-
-// DigestFile will return a valid Digest instance based on a file,
-// using a cached digest if available
-func DigestFile(eventFilePath string, users UsernameSet) (*Digest, error) {
-	digestFilePath := fmt.Sprintf("%v.digest.json", eventFilePath)
-	df, err := os.OpenFile(digestFilePath,
-		os.O_EXCL|os.O_CREATE|os.O_RDWR,
-		0664)
+// digestFile returns a valid Digest for eventFilePath, using s's cached
+// digest if one is stored for the file's current content ID. Digests are
+// stored content-addressed, so a changed eventFilePath hashes to a
+// different id and simply misses the cache rather than requiring an
+// explicit staleness check.
+func digestFile(s store.Store, eventFilePath string,
+	users extractor.UsernameSet) (*digest.Digest, error) {
+	id, err := extractor.SourceID(eventFilePath)
 	if err != nil {
-		if os.IsExist(err) {
-			return readDigest(digestFilePath)
-		}
 		return nil, err
 	}
-	defer df.Close()
 
-	return doDigestFile(eventFilePath, df, users)
+	if d, err := s.GetDigest(id); err == nil {
+		return d, nil
+	}
+
+	return computeDigest(s, eventFilePath, id, users)
 }
 
-func doDigestFile(eventFilePath string, digestFile *os.File,
-	users UsernameSet) (*Digest, error) {
+// computeDigest scans eventFilePath exactly once, counting its events
+// and extracting their usernames in the same pass, builds the resulting
+// Digest, and stores it under id
+func computeDigest(s store.Store, eventFilePath string, id digest.ID,
+	users extractor.UsernameSet) (*digest.Digest, error) {
 	f, err := os.Open(eventFilePath)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	reader, err := gzip.NewReader(f)
-	if err != nil {
-		panic(err)
-	}
-
-	c, err := lineCounter(reader)
-	if err != nil {
-		panic(err)
-	}
-	if _, err := f.Seek(0, 0); err != nil {
-		panic(err)
-	}
-
-	reader.Reset(f)
-
-	err = usernameExtractor(reader, users)
+	r, err := extractor.Open(eventFilePath, f)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	defer r.Close()
 
-	dateParts := eventFilenameRE.FindStringSubmatch(
-		filepath.Base(eventFilePath))
-	year, _ := strconv.Atoi(dateParts[1])
-	month, _ := strconv.Atoi(dateParts[2])
-	day, _ := strconv.Atoi(dateParts[3])
-	hr, _ := strconv.Atoi(dateParts[4])
-	fileDate := time.Date(
-		year, time.Month(month), day, hr,
-		0, 0, 0, time.UTC)
-	digest := &Digest{
-		Count: c,
-		Date:  fileDate,
-	}
-	if err != nil {
+	scanner := extractor.NewScanner(r)
+	if err := scanner.Scan(users); err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("computed %v: %v\n", fileDate, c)
-	err = json.NewEncoder(digestFile).Encode(digest)
-	return digest, err
-}
-
-func readDigest(digestFilePath string) (*Digest, error) {
-	f, err := os.Open(digestFilePath)
+	d, err := digest.New(eventFilePath, scanner.Count(), id)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
-	d := &Digest{}
-	err = json.NewDecoder(f).Decode(d)
-	return d, err
+	fmt.Printf("computed %v: %v\n", d.Date, scanner.Count())
+	return d, s.PutDigest(id, d)
 }
 
-func lineCounter(r io.Reader) (int, error) {
-	buf := make([]byte, 1024*1024)
-	count := 0
-	lineSep := []byte{'\n'}
-
-	for {
-		c, err := r.Read(buf)
-		if err != nil && err != io.EOF {
-			return count, err
+// digestFiles fans digestFile out across a bounded worker pool, merging
+// each worker's usernames into users as they complete
+func digestFiles(s store.Store, eventFiles []string,
+	users extractor.UsernameSet) []*digest.Digest {
+	jobs := make(chan string)
+	results := make(chan *digest.Digest)
+
+	var processed int64
+	workers := concurrency()
+	fmt.Printf("digesting %v files with %v workers\n", len(eventFiles), workers)
+
+	var wg sync.WaitGroup
+	var usersMu sync.Mutex
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				local := extractor.UsernameSet{}
+				d, err := digestFile(s, f, local)
+				if err != nil {
+					panic(err)
+				}
+
+				usersMu.Lock()
+				for u := range local {
+					users.Add(u)
+				}
+				atomic.AddInt64(&processed, 1)
+				fmt.Printf("now have %v users (%v/%v files)\n",
+					len(users), atomic.LoadInt64(&processed), len(eventFiles))
+				usersMu.Unlock()
+
+				results <- d
+			}
+		}()
+	}
+
+	go func() {
+		for _, f := range eventFiles {
+			jobs <- f
 		}
+		close(jobs)
+	}()
 
-		count += bytes.Count(buf[:c], lineSep)
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-		if err == io.EOF {
-			break
-		}
+	digests := make([]*digest.Digest, 0, len(eventFiles))
+	for d := range results {
+		digests = append(digests, d)
 	}
-
-	return count, nil
+	return digests
 }
 
-func usernameExtractor(r io.Reader, users UsernameSet) error {
-	decoder := json.NewDecoder(r)
-	for {
-		if !decoder.More() {
-			break
-		}
-		event := EventRecord{}
-		err := decoder.Decode(&event)
+// verifyDigests re-hashes every event file and reports any whose current
+// content ID has no cached digest (a re-downloaded or truncated archive,
+// for instance, hashes differently and so drops out of the cache)
+func verifyDigests(s store.Store) error {
+	files, err := extractor.Glob(os.Getenv("GHC_EVENTS_PATH"))
+	if err != nil {
+		return err
+	}
+
+	drifted := 0
+	for _, f := range files {
+		id, err := extractor.SourceID(f)
 		if err != nil {
 			return err
 		}
-		event.Actor.Username = strings.ToLower(event.Actor.Username)
-		users.Add(Username(event.Actor.Username))
-	}
-	return nil
-}
 
-func makePath(basename string) string {
-	return filepath.Join(
-		os.Getenv("GHC_EVENTS_PATH"),
-		basename)
-}
-
-func makeSummary(digests DigestSlice, newUsers UsernameSet) {
-	digests = DigestSlice(digests).SortBy(func(x, y *Digest) bool {
-		return x.Date.Unix() < y.Date.Unix()
-	})
-
-	digestSummary, err := os.Create(makePath("summary.json"))
-	if err != nil {
-		panic(err)
+		// content-addressed storage means a hit here is guaranteed to
+		// have SourceID == id by construction (see PutDigest); drift
+		// shows up purely as a cache miss for the file's current id
+		_, err = s.GetDigest(id)
+		switch {
+		case os.IsNotExist(err):
+			fmt.Printf("drift: %v: no cached digest for current contents (%v)\n",
+				f, id)
+			drifted++
+		case err != nil:
+			return err
+		}
 	}
-	defer digestSummary.Close()
 
-	err = json.NewEncoder(digestSummary).Encode(digests)
-	if err != nil {
-		panic(err)
-	}
+	fmt.Printf("verify complete: %v file(s) drifted\n", drifted)
+	return nil
+}
 
-	usersSummary, err := os.OpenFile(
-		makePath("users.txt"),
-		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
-		0664)
-	if err != nil {
-		panic(err)
-	}
-	defer usersSummary.Close()
+func main() {
+	s := newStore()
 
-	fmt.Printf("writing %v users\n", len(newUsers))
-	for u := range newUsers {
-		_, err = fmt.Fprintln(usersSummary, u)
-		if err != nil {
+	if len(os.Args) > 1 && os.Args[1] == "--verify" {
+		if err := verifyDigests(s); err != nil {
 			panic(err)
 		}
+		return
 	}
-}
 
-func readKnownUsers() UsernameSet {
-	users := UsernameSet{}
-	usersBuf, err := ioutil.ReadFile(makePath("users.txt"))
-	if err == nil {
-		userStrings := strings.Split(string(usersBuf), "\n")
-		for _, u := range userStrings {
-			users.Add(Username(u))
-		}
-	} else {
-		fmt.Printf("warning: could not read users.txt: %v\n", err)
-	}
-	return users
-}
-
-func main() {
 	fmt.Println("reading users...")
-	users := readKnownUsers()
+	users, err := s.LoadUsers()
+	if err != nil {
+		fmt.Printf("warning: could not load users: %v\n", err)
+		users = extractor.UsernameSet{}
+	}
 	existingUsers := users.Clone()
 	fmt.Printf("found %v existing users\n", len(existingUsers))
 
-	eventFiles, err := filepath.Glob(makePath("*.json.gz"))
+	files, err := extractor.Glob(os.Getenv("GHC_EVENTS_PATH"))
 	if err != nil {
 		panic(err)
 	}
 
-	digests := make([]*Digest, 0, len(eventFiles))
-	for _, f := range eventFiles {
-		d, err := DigestFile(f, users)
-		if err != nil {
-			panic(err)
-		}
-		fmt.Printf("now have %v users\n", len(users))
-		digests = append(digests, d)
-	}
+	digests := digestFiles(s, files, users)
 
 	fmt.Println("computing difference in users")
 	newUsers := users.Difference(existingUsers)
 	fmt.Printf("done (found %v)\n", len(newUsers))
-	makeSummary(digests, newUsers)
+
+	sorted := digest.SortByDate(digest.DigestSlice(digests))
+	if err := s.PutSummary(sorted); err != nil {
+		panic(err)
+	}
+
+	newUserList := make([]extractor.Username, 0, len(newUsers))
+	for u := range newUsers {
+		newUserList = append(newUserList, u)
+	}
+	fmt.Printf("writing %v users\n", len(newUserList))
+	if err := s.AppendUsers(newUserList); err != nil {
+		panic(err)
+	}
 }
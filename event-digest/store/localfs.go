@@ -0,0 +1,341 @@
+package store
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/modulexcite/github-contributions/event-digest/digest"
+	"github.com/modulexcite/github-contributions/event-digest/digestpb"
+	"github.com/modulexcite/github-contributions/event-digest/extractor"
+)
+
+// LocalFSStore is the original on-disk layout: digests sharded under
+// <EventsPath>/digests/<id-prefix>/<id>.json and/or <id>.pb, users
+// recorded in <EventsPath>/users.txt, and the run summary at
+// <EventsPath>/summary.json and/or summary.pb
+type LocalFSStore struct {
+	EventsPath string
+	Encoding   digest.Encoding
+	// Compression is the on-disk compression applied to digest and
+	// summary files: "", "gzip", or "zstd"
+	Compression string
+}
+
+func (s *LocalFSStore) path(basename string) string {
+	return filepath.Join(s.EventsPath, basename)
+}
+
+func (s *LocalFSStore) digestPath(id digest.ID, ext string) string {
+	h := id.String()
+	return filepath.Join(s.path("digests"), h[:2], h+ext)
+}
+
+type compressedWriteCloser struct {
+	io.WriteCloser
+	f *os.File
+}
+
+func (c *compressedWriteCloser) Close() error {
+	if err := c.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return c.f.Close()
+}
+
+type compressedReadCloser struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (c *compressedReadCloser) Close() error {
+	if err := c.ReadCloser.Close(); err != nil {
+		return err
+	}
+	return c.f.Close()
+}
+
+// create creates path, or a compressed variant of it named with the
+// appropriate suffix, according to s.Compression
+func (s *LocalFSStore) create(path string) (io.WriteCloser, error) {
+	switch s.Compression {
+	case "gzip":
+		f, err := os.Create(path + ".gz")
+		if err != nil {
+			return nil, err
+		}
+		return &compressedWriteCloser{gzip.NewWriter(f), f}, nil
+	case "zstd":
+		f, err := os.Create(path + ".zst")
+		if err != nil {
+			return nil, err
+		}
+		w, err := zstd.NewWriter(f)
+		if err != nil {
+			return nil, err
+		}
+		return &compressedWriteCloser{w, f}, nil
+	default:
+		return os.Create(path)
+	}
+}
+
+// open opens path, or its compressed variant, according to s.Compression
+func (s *LocalFSStore) open(path string) (io.ReadCloser, error) {
+	switch s.Compression {
+	case "gzip":
+		f, err := os.Open(path + ".gz")
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		return &compressedReadCloser{gz, f}, nil
+	case "zstd":
+		f, err := os.Open(path + ".zst")
+		if err != nil {
+			return nil, err
+		}
+		d, err := zstd.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		return &compressedReadCloser{d.IOReadCloser(), f}, nil
+	default:
+		return os.Open(path)
+	}
+}
+
+func (s *LocalFSStore) readDigestJSON(path string) (*digest.Digest, error) {
+	f, err := s.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := &digest.Digest{}
+	err = json.NewDecoder(f).Decode(d)
+	return d, err
+}
+
+func (s *LocalFSStore) readDigestProto(path string) (*digest.Digest, error) {
+	f, err := s.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &digestpb.Digest{}
+	if err := proto.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	return digest.FromProto(p)
+}
+
+// GetDigest loads the cached digest for id, preferring whichever
+// encoding s.Encoding selects (json for the default and "both" cases,
+// since both are kept in sync by PutDigest)
+func (s *LocalFSStore) GetDigest(id digest.ID) (*digest.Digest, error) {
+	if s.Encoding == digest.EncodingProto {
+		return s.readDigestProto(s.digestPath(id, ".pb"))
+	}
+	return s.readDigestJSON(s.digestPath(id, ".json"))
+}
+
+// PutDigest persists d under id according to s.Encoding. When
+// s.Encoding is EncodingBoth it writes parallel .json/.pb files and
+// reads both back to confirm they agree, failing loudly if they've
+// diverged
+func (s *LocalFSStore) PutDigest(id digest.ID, d *digest.Digest) error {
+	if err := os.MkdirAll(filepath.Dir(s.digestPath(id, ".json")), 0775); err != nil {
+		return err
+	}
+
+	if s.Encoding == digest.EncodingJSON || s.Encoding == digest.EncodingBoth {
+		f, err := s.create(s.digestPath(id, ".json"))
+		if err != nil {
+			return err
+		}
+		err = json.NewEncoder(f).Encode(d)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if s.Encoding == digest.EncodingProto || s.Encoding == digest.EncodingBoth {
+		b, err := proto.Marshal(digest.ToProto(d))
+		if err != nil {
+			return err
+		}
+		f, err := s.create(s.digestPath(id, ".pb"))
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(b)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if s.Encoding != digest.EncodingBoth {
+		return nil
+	}
+
+	jsonDigest, err := s.readDigestJSON(s.digestPath(id, ".json"))
+	if err != nil {
+		return err
+	}
+	protoDigest, err := s.readDigestProto(s.digestPath(id, ".pb"))
+	if err != nil {
+		return err
+	}
+	if !digest.Equal(jsonDigest, protoDigest) {
+		return fmt.Errorf("digest encodings diverged for %v: json=%+v proto=%+v",
+			id, jsonDigest, protoDigest)
+	}
+	return nil
+}
+
+// PutSummary persists digests according to s.Encoding, mirroring
+// PutDigest's json/proto/both handling
+func (s *LocalFSStore) PutSummary(digests digest.DigestSlice) error {
+	if s.Encoding == digest.EncodingJSON || s.Encoding == digest.EncodingBoth {
+		f, err := s.create(s.path("summary.json"))
+		if err != nil {
+			return err
+		}
+		err = json.NewEncoder(f).Encode(digests)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if s.Encoding == digest.EncodingProto || s.Encoding == digest.EncodingBoth {
+		summary := &digestpb.Summary{}
+		for _, d := range digests {
+			summary.Digests = append(summary.Digests, digest.ToProto(d))
+		}
+
+		b, err := proto.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		f, err := s.create(s.path("summary.pb"))
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(b)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if s.Encoding != digest.EncodingBoth {
+		return nil
+	}
+
+	jsonDigests, err := s.readSummaryJSON(s.path("summary.json"))
+	if err != nil {
+		return err
+	}
+	protoDigests, err := s.readSummaryProto(s.path("summary.pb"))
+	if err != nil {
+		return err
+	}
+	if len(jsonDigests) != len(protoDigests) {
+		return fmt.Errorf("summary encodings diverged: json has %v digests, proto has %v",
+			len(jsonDigests), len(protoDigests))
+	}
+	for i := range jsonDigests {
+		if !digest.Equal(jsonDigests[i], protoDigests[i]) {
+			return fmt.Errorf("summary encodings diverged at index %v: json=%+v proto=%+v",
+				i, jsonDigests[i], protoDigests[i])
+		}
+	}
+	return nil
+}
+
+func (s *LocalFSStore) readSummaryJSON(path string) (digest.DigestSlice, error) {
+	f, err := s.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var digests digest.DigestSlice
+	err = json.NewDecoder(f).Decode(&digests)
+	return digests, err
+}
+
+func (s *LocalFSStore) readSummaryProto(path string) (digest.DigestSlice, error) {
+	f, err := s.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &digestpb.Summary{}
+	if err := proto.Unmarshal(b, summary); err != nil {
+		return nil, err
+	}
+
+	digests := make(digest.DigestSlice, len(summary.Digests))
+	for i, p := range summary.Digests {
+		d, err := digest.FromProto(p)
+		if err != nil {
+			return nil, err
+		}
+		digests[i] = d
+	}
+	return digests, nil
+}
+
+// AppendUsers records newly seen usernames in users.txt
+func (s *LocalFSStore) AppendUsers(users []extractor.Username) error {
+	f, err := os.OpenFile(s.path("users.txt"),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, u := range users {
+		if _, err := fmt.Fprintln(f, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadUsers reads every username recorded in users.txt
+func (s *LocalFSStore) LoadUsers() (extractor.UsernameSet, error) {
+	users := extractor.UsernameSet{}
+	buf, err := ioutil.ReadFile(s.path("users.txt"))
+	if err != nil {
+		return users, err
+	}
+	for _, u := range strings.Split(string(buf), "\n") {
+		users.Add(extractor.Username(u))
+	}
+	return users, nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/modulexcite/github-contributions/event-digest/digest"
+	"github.com/modulexcite/github-contributions/event-digest/digestpb"
+)
+
+func newTestStore(t *testing.T, encoding digest.Encoding) (*LocalFSStore, func()) {
+	dir, err := ioutil.TempDir("", "localfs-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+
+	return &LocalFSStore{EventsPath: dir, Encoding: encoding}, func() { os.RemoveAll(dir) }
+}
+
+func TestLocalFSStorePutThenGetDigestRoundTrips(t *testing.T) {
+	s, cleanup := newTestStore(t, digest.EncodingBoth)
+	defer cleanup()
+
+	id := digest.ID{1, 2, 3}
+	d := &digest.Digest{Count: 7, Date: time.Unix(1000, 0).UTC(), SourceID: id}
+
+	if err := s.PutDigest(id, d); err != nil {
+		t.Fatalf("PutDigest: %v", err)
+	}
+
+	got, err := s.GetDigest(id)
+	if err != nil {
+		t.Fatalf("GetDigest: %v", err)
+	}
+	if !digest.Equal(d, got) {
+		t.Errorf("GetDigest(%v) = %+v, want %+v", id, got, d)
+	}
+}
+
+func TestLocalFSStoreGetDigestMissOnUnknownID(t *testing.T) {
+	s, cleanup := newTestStore(t, digest.EncodingJSON)
+	defer cleanup()
+
+	if _, err := s.GetDigest(digest.ID{9}); err == nil {
+		t.Error("GetDigest for unknown id: got nil error, want non-nil")
+	}
+}
+
+func TestLocalFSStoreGetDigestRejectsCorruptProto(t *testing.T) {
+	s, cleanup := newTestStore(t, digest.EncodingProto)
+	defer cleanup()
+
+	id := digest.ID{4, 5, 6}
+	path := s.digestPath(id, ".pb")
+	if err := os.MkdirAll(filepath.Dir(path), 0775); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	b, err := proto.Marshal(&digestpb.Digest{
+		SourceId: id[:len(id)-1],
+		Count:    1,
+		DateUnix: 0,
+	})
+	if err != nil {
+		t.Fatalf("proto.Marshal: %v", err)
+	}
+	if err := ioutil.WriteFile(path, b, 0664); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := s.GetDigest(id); err == nil {
+		t.Error("GetDigest for corrupt proto digest: got nil error, want non-nil")
+	}
+}
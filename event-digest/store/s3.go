@@ -0,0 +1,42 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/modulexcite/github-contributions/event-digest/digest"
+	"github.com/modulexcite/github-contributions/event-digest/extractor"
+)
+
+// S3Store is a stub backend for pointing directly at an S3-compatible GH
+// Archive mirror instead of a LocalFSStore checkout. It documents the
+// shape a remote backend needs to fill in; wiring up a real client is
+// left for a follow-up.
+type S3Store struct {
+	Bucket string
+	Prefix string
+}
+
+func (s *S3Store) notImplemented(method string) error {
+	return fmt.Errorf("s3store: %v not implemented (bucket %v, prefix %v)",
+		method, s.Bucket, s.Prefix)
+}
+
+func (s *S3Store) GetDigest(id digest.ID) (*digest.Digest, error) {
+	return nil, s.notImplemented("GetDigest")
+}
+
+func (s *S3Store) PutDigest(id digest.ID, d *digest.Digest) error {
+	return s.notImplemented("PutDigest")
+}
+
+func (s *S3Store) PutSummary(digests digest.DigestSlice) error {
+	return s.notImplemented("PutSummary")
+}
+
+func (s *S3Store) AppendUsers(users []extractor.Username) error {
+	return s.notImplemented("AppendUsers")
+}
+
+func (s *S3Store) LoadUsers() (extractor.UsernameSet, error) {
+	return nil, s.notImplemented("LoadUsers")
+}
@@ -0,0 +1,24 @@
+// Package store persists digests and known usernames behind a pluggable
+// backend, so the rest of event-digest doesn't need to know whether it's
+// talking to the local filesystem or a remote mirror.
+package store
+
+import (
+	"github.com/modulexcite/github-contributions/event-digest/digest"
+	"github.com/modulexcite/github-contributions/event-digest/extractor"
+)
+
+// Store is implemented by each supported persistence backend
+type Store interface {
+	// GetDigest returns the cached digest for id, or an error satisfying
+	// os.IsNotExist if none has been stored yet
+	GetDigest(id digest.ID) (*digest.Digest, error)
+	// PutDigest stores d under id
+	PutDigest(id digest.ID, d *digest.Digest) error
+	// PutSummary persists the full, sorted set of digests for a run
+	PutSummary(digests digest.DigestSlice) error
+	// AppendUsers records newly seen usernames
+	AppendUsers(users []extractor.Username) error
+	// LoadUsers returns every username seen in previous runs
+	LoadUsers() (extractor.UsernameSet, error)
+}